@@ -0,0 +1,71 @@
+// Package accounts loads the account list used to fan a scan out across
+// multiple AWS accounts via assume-role.
+package accounts
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Account is one entry in the -accounts YAML file: an account ID and the IAM
+// role name to assume in it. Partition and Region are optional and default
+// to "aws" and the tool's standard CloudFront region; set them to scan
+// accounts in another AWS partition, e.g. aws-cn.
+type Account struct {
+	ID        string `yaml:"id"`
+	Role      string `yaml:"role"`
+	Partition string `yaml:"partition"`
+	Region    string `yaml:"region"`
+}
+
+// config is the top-level shape of the -accounts YAML file:
+//
+//	accounts:
+//	  - id: "111122223333"
+//	    role: CloudFrontInspectorReadOnly
+//	  - id: "444455556666"
+//	    role: CloudFrontInspectorReadOnly
+//	  - id: "777788889999"
+//	    role: CloudFrontInspectorReadOnly
+//	    partition: aws-cn
+//	    region: cn-north-1
+type config struct {
+	Accounts []Account `yaml:"accounts"`
+}
+
+// Load reads and validates the account list at path.
+func Load(path string) ([]Account, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("accounts: failed to read %s: %w", path, err)
+	}
+
+	var cfg config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("accounts: failed to parse %s: %w", path, err)
+	}
+
+	if len(cfg.Accounts) == 0 {
+		return nil, fmt.Errorf("accounts: %s lists no accounts", path)
+	}
+
+	for i, acct := range cfg.Accounts {
+		if acct.ID == "" {
+			return nil, fmt.Errorf("accounts: entry %d in %s is missing an id", i, path)
+		}
+		if acct.Role == "" {
+			return nil, fmt.Errorf("accounts: entry %d in %s is missing a role", i, path)
+		}
+		// A non-default partition and its region must be set together: a
+		// partition's STS/CloudFront regions aren't valid in another
+		// partition, so defaulting one while the other is explicit would
+		// silently target the wrong partition.
+		if acct.Partition != "" && acct.Partition != "aws" && acct.Region == "" {
+			return nil, fmt.Errorf("accounts: entry %d in %s sets partition %q but no region", i, path, acct.Partition)
+		}
+	}
+
+	return cfg.Accounts, nil
+}
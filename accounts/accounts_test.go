@@ -0,0 +1,129 @@
+package accounts
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeAccountsFile(t *testing.T, contents string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "accounts.yaml")
+	if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+		t.Fatalf("failed to write test fixture: %v", err)
+	}
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name       string
+		contents   string
+		wantErr    bool
+		wantErrMsg string
+		want       []Account
+	}{
+		{
+			name: "missing id",
+			contents: `
+accounts:
+  - role: CloudFrontInspectorReadOnly
+`,
+			wantErr:    true,
+			wantErrMsg: "missing an id",
+		},
+		{
+			name: "missing role",
+			contents: `
+accounts:
+  - id: "111122223333"
+`,
+			wantErr:    true,
+			wantErrMsg: "missing a role",
+		},
+		{
+			name: "omitted partition and region are left empty",
+			contents: `
+accounts:
+  - id: "111122223333"
+    role: CloudFrontInspectorReadOnly
+`,
+			want: []Account{
+				{ID: "111122223333", Role: "CloudFrontInspectorReadOnly"},
+			},
+		},
+		{
+			name: "explicit partition and region",
+			contents: `
+accounts:
+  - id: "777788889999"
+    role: CloudFrontInspectorReadOnly
+    partition: aws-cn
+    region: cn-north-1
+`,
+			want: []Account{
+				{ID: "777788889999", Role: "CloudFrontInspectorReadOnly", Partition: "aws-cn", Region: "cn-north-1"},
+			},
+		},
+		{
+			name: "non-default partition without region",
+			contents: `
+accounts:
+  - id: "777788889999"
+    role: CloudFrontInspectorReadOnly
+    partition: aws-cn
+`,
+			wantErr:    true,
+			wantErrMsg: "sets partition \"aws-cn\" but no region",
+		},
+		{
+			name: "explicit default partition without region is fine",
+			contents: `
+accounts:
+  - id: "111122223333"
+    role: CloudFrontInspectorReadOnly
+    partition: aws
+`,
+			want: []Account{
+				{ID: "111122223333", Role: "CloudFrontInspectorReadOnly", Partition: "aws"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeAccountsFile(t, tt.contents)
+
+			got, err := Load(path)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Load() error = nil, want error containing %q", tt.wantErrMsg)
+				}
+				if !strings.Contains(err.Error(), tt.wantErrMsg) {
+					t.Errorf("Load() error = %q, want it to contain %q", err.Error(), tt.wantErrMsg)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("Load() returned %d accounts, want %d", len(got), len(tt.want))
+			}
+			for i, acct := range got {
+				if acct != tt.want[i] {
+					t.Errorf("Load()[%d] = %+v, want %+v", i, acct, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestLoad_NoAccounts(t *testing.T) {
+	path := writeAccountsFile(t, "accounts: []\n")
+
+	if _, err := Load(path); err == nil {
+		t.Errorf("Load() error = nil, want error for a file listing no accounts")
+	}
+}
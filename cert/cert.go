@@ -0,0 +1,162 @@
+// Package cert correlates a CloudFront distribution's viewer certificate
+// with the distribution's own aliases, so callers can flag certificates that
+// are expiring soon or that don't actually cover every alias configured on
+// the distribution.
+package cert
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/acm"
+	acmtypes "github.com/aws/aws-sdk-go-v2/service/acm/types"
+	cftypes "github.com/aws/aws-sdk-go-v2/service/cloudfront/types"
+)
+
+// DomainValidation is one entry of an ACM certificate's DomainValidationOptions:
+// the domain being validated and how far along that validation is.
+type DomainValidation struct {
+	DomainName       string
+	ValidationStatus string
+	ValidationMethod string
+}
+
+// Info describes the certificate protecting a distribution and how well it
+// covers the distribution's aliases.
+type Info struct {
+	// ARN is the ACM certificate ARN, or empty if the distribution uses an
+	// IAM server certificate or the CloudFront default certificate.
+	ARN string
+	// IAMCertificateID is set instead of ARN when the distribution uses an
+	// IAM-uploaded certificate, which ACM can't describe.
+	IAMCertificateID string
+	// Expiration is the ACM certificate's NotAfter date. Zero if unknown
+	// (IAM certificates or the CloudFront default certificate).
+	Expiration time.Time
+	// SubjectAlternativeNames is the full SAN list on the ACM certificate.
+	SubjectAlternativeNames []string
+	// UncoveredAliases are distribution aliases not present in
+	// SubjectAlternativeNames. Always empty for IAM/default certificates,
+	// since there's nothing to check them against.
+	UncoveredAliases []string
+	// DomainValidations mirrors the certificate's DomainValidationOptions,
+	// so callers can see which domains are still pending validation. Empty
+	// for IAM/default certificates.
+	DomainValidations []DomainValidation
+	// Error is set by callers that couldn't correlate a certificate at all
+	// (e.g. DescribeCertificate failed) so that a lookup failure renders
+	// distinctly from a distribution that genuinely has no certificate to
+	// flag. Correlate itself never sets this; it returns an error instead.
+	Error string
+}
+
+// acmAPI is the subset of *acm.Client that Correlate depends on, narrowed so
+// tests can exercise the certificate correlation logic against a fake
+// instead of a live ACM account.
+type acmAPI interface {
+	DescribeCertificate(ctx context.Context, params *acm.DescribeCertificateInput, optFns ...func(*acm.Options)) (*acm.DescribeCertificateOutput, error)
+}
+
+// Correlate inspects vc and, for ACM-backed certificates, calls
+// acm.DescribeCertificate (which must run against us-east-1, since that's
+// where CloudFront-eligible ACM certificates live) to determine expiration
+// and which of the distribution's aliases the certificate actually covers.
+func Correlate(ctx context.Context, acmClient acmAPI, vc *cftypes.ViewerCertificate, aliases []string) (*Info, error) {
+	if vc == nil {
+		return &Info{}, nil
+	}
+
+	if vc.IAMCertificateId != nil {
+		return &Info{IAMCertificateID: *vc.IAMCertificateId}, nil
+	}
+
+	if vc.ACMCertificateArn == nil {
+		// Neither ACM nor IAM is set: the distribution is using the default
+		// *.cloudfront.net certificate.
+		return &Info{}, nil
+	}
+
+	arn := *vc.ACMCertificateArn
+	describeOutput, err := acmClient.DescribeCertificate(ctx, &acm.DescribeCertificateInput{
+		CertificateArn: aws.String(arn),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("cert: failed to describe certificate %s: %w", arn, err)
+	}
+
+	sans := describeOutput.Certificate.SubjectAlternativeNames
+	info := &Info{
+		ARN:                     arn,
+		SubjectAlternativeNames: sans,
+		UncoveredAliases:        uncoveredAliases(aliases, sans),
+		DomainValidations:       domainValidations(describeOutput.Certificate.DomainValidationOptions),
+	}
+	if describeOutput.Certificate.NotAfter != nil {
+		info.Expiration = *describeOutput.Certificate.NotAfter
+	}
+	return info, nil
+}
+
+// domainValidations converts ACM's DomainValidationOptions into the package's
+// own DomainValidation type.
+func domainValidations(options []acmtypes.DomainValidation) []DomainValidation {
+	validations := make([]DomainValidation, 0, len(options))
+	for _, opt := range options {
+		validations = append(validations, DomainValidation{
+			DomainName:       aws.ToString(opt.DomainName),
+			ValidationStatus: string(opt.ValidationStatus),
+			ValidationMethod: string(opt.ValidationMethod),
+		})
+	}
+	return validations
+}
+
+// uncoveredAliases returns the aliases not matched by any entry in sans,
+// honoring wildcard SANs such as "*.example.com".
+func uncoveredAliases(aliases, sans []string) []string {
+	var uncovered []string
+	for _, alias := range aliases {
+		if !coveredBy(alias, sans) {
+			uncovered = append(uncovered, alias)
+		}
+	}
+	return uncovered
+}
+
+func coveredBy(alias string, sans []string) bool {
+	for _, san := range sans {
+		if san == alias {
+			return true
+		}
+		if matchesWildcard(san, alias) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesWildcard reports whether a "*.example.com"-style SAN covers alias.
+// Wildcards only cover a single label, matching ACM/TLS semantics.
+func matchesWildcard(san, alias string) bool {
+	const wildcardPrefix = "*."
+	if len(san) <= len(wildcardPrefix) || san[:len(wildcardPrefix)] != wildcardPrefix {
+		return false
+	}
+	suffix := san[len(wildcardPrefix)-1:] // ".example.com"
+	if len(alias) <= len(suffix) || alias[len(alias)-len(suffix):] != suffix {
+		return false
+	}
+	label := alias[:len(alias)-len(suffix)]
+	return label != "" && !containsDot(label)
+}
+
+func containsDot(s string) bool {
+	for _, r := range s {
+		if r == '.' {
+			return true
+		}
+	}
+	return false
+}
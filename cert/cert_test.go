@@ -0,0 +1,198 @@
+package cert
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/acm"
+	acmtypes "github.com/aws/aws-sdk-go-v2/service/acm/types"
+	cftypes "github.com/aws/aws-sdk-go-v2/service/cloudfront/types"
+)
+
+// fakeACM is a test double for acmAPI.
+type fakeACM struct {
+	output *acm.DescribeCertificateOutput
+	err    error
+}
+
+func (f *fakeACM) DescribeCertificate(_ context.Context, _ *acm.DescribeCertificateInput, _ ...func(*acm.Options)) (*acm.DescribeCertificateOutput, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return f.output, nil
+}
+
+func TestUncoveredAliases(t *testing.T) {
+	tests := []struct {
+		name    string
+		aliases []string
+		sans    []string
+		want    []string
+	}{
+		{
+			name:    "exact match is covered",
+			aliases: []string{"www.example.com"},
+			sans:    []string{"www.example.com"},
+		},
+		{
+			name:    "wildcard covers a single subdomain",
+			aliases: []string{"a.example.com"},
+			sans:    []string{"*.example.com"},
+		},
+		{
+			name:    "wildcard does not cover the apex",
+			aliases: []string{"example.com"},
+			sans:    []string{"*.example.com"},
+			want:    []string{"example.com"},
+		},
+		{
+			name:    "wildcard does not cover a second-level subdomain",
+			aliases: []string{"a.b.example.com"},
+			sans:    []string{"*.example.com"},
+			want:    []string{"a.b.example.com"},
+		},
+		{
+			name:    "alias not present in any SAN",
+			aliases: []string{"other.example.com"},
+			sans:    []string{"www.example.com"},
+			want:    []string{"other.example.com"},
+		},
+		{
+			name:    "mix of covered and uncovered aliases",
+			aliases: []string{"a.example.com", "other.example.org"},
+			sans:    []string{"*.example.com"},
+			want:    []string{"other.example.org"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := uncoveredAliases(tt.aliases, tt.sans)
+			if len(got) != len(tt.want) {
+				t.Fatalf("uncoveredAliases() = %v, want %v", got, tt.want)
+			}
+			for i, alias := range got {
+				if alias != tt.want[i] {
+					t.Errorf("uncoveredAliases()[%d] = %q, want %q", i, alias, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestMatchesWildcard(t *testing.T) {
+	tests := []struct {
+		name  string
+		san   string
+		alias string
+		want  bool
+	}{
+		{name: "covers direct subdomain", san: "*.example.com", alias: "a.example.com", want: true},
+		{name: "does not cover apex", san: "*.example.com", alias: "example.com", want: false},
+		{name: "does not cover nested subdomain", san: "*.example.com", alias: "a.b.example.com", want: false},
+		{name: "does not cover a different domain", san: "*.example.com", alias: "a.example.org", want: false},
+		{name: "non-wildcard SAN never matches", san: "a.example.com", alias: "a.example.com", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchesWildcard(tt.san, tt.alias); got != tt.want {
+				t.Errorf("matchesWildcard(%q, %q) = %v, want %v", tt.san, tt.alias, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestCorrelate_NoACMLookup covers the viewer certificate shapes Correlate
+// can resolve without calling DescribeCertificate: no certificate at all, an
+// IAM-uploaded certificate, and the CloudFront default certificate.
+func TestCorrelate_NoACMLookup(t *testing.T) {
+	tests := []struct {
+		name string
+		vc   *cftypes.ViewerCertificate
+		want Info
+	}{
+		{
+			name: "nil viewer certificate",
+			vc:   nil,
+			want: Info{},
+		},
+		{
+			name: "IAM server certificate",
+			vc:   &cftypes.ViewerCertificate{IAMCertificateId: aws.String("ASCAEXAMPLE")},
+			want: Info{IAMCertificateID: "ASCAEXAMPLE"},
+		},
+		{
+			name: "CloudFront default certificate",
+			vc:   &cftypes.ViewerCertificate{},
+			want: Info{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info, err := Correlate(context.Background(), nil, tt.vc, []string{"www.example.com"})
+			if err != nil {
+				t.Fatalf("Correlate() error = %v", err)
+			}
+			if info.ARN != tt.want.ARN || info.IAMCertificateID != tt.want.IAMCertificateID || len(info.UncoveredAliases) != 0 {
+				t.Errorf("Correlate() = %+v, want %+v", info, tt.want)
+			}
+		})
+	}
+}
+
+// TestCorrelate_DescribeCertificate covers the ACM lookup path: expiration,
+// SubjectAlternativeNames, DomainValidations and uncovered-alias detection
+// all derive from the DescribeCertificate response.
+func TestCorrelate_DescribeCertificate(t *testing.T) {
+	const arn = "arn:aws:acm:us-east-1:111122223333:certificate/abc"
+	expiration := time.Date(2030, time.January, 1, 0, 0, 0, 0, time.UTC)
+	vc := &cftypes.ViewerCertificate{ACMCertificateArn: aws.String(arn)}
+
+	client := &fakeACM{
+		output: &acm.DescribeCertificateOutput{
+			Certificate: &acmtypes.CertificateDetail{
+				CertificateArn:          aws.String(arn),
+				NotAfter:                aws.Time(expiration),
+				SubjectAlternativeNames: []string{"www.example.com", "*.example.com"},
+				DomainValidationOptions: []acmtypes.DomainValidation{
+					{DomainName: aws.String("www.example.com"), ValidationStatus: acmtypes.DomainStatusSuccess, ValidationMethod: acmtypes.ValidationMethodDns},
+				},
+			},
+		},
+	}
+
+	info, err := Correlate(context.Background(), client, vc, []string{"www.example.com", "other.example.org"})
+	if err != nil {
+		t.Fatalf("Correlate() error = %v", err)
+	}
+
+	if info.ARN != arn {
+		t.Errorf("Correlate() ARN = %q, want %q", info.ARN, arn)
+	}
+	if !info.Expiration.Equal(expiration) {
+		t.Errorf("Correlate() Expiration = %v, want %v", info.Expiration, expiration)
+	}
+	if len(info.SubjectAlternativeNames) != 2 {
+		t.Errorf("Correlate() SubjectAlternativeNames = %v, want 2 entries", info.SubjectAlternativeNames)
+	}
+	if len(info.UncoveredAliases) != 1 || info.UncoveredAliases[0] != "other.example.org" {
+		t.Errorf("Correlate() UncoveredAliases = %v, want [other.example.org]", info.UncoveredAliases)
+	}
+	if len(info.DomainValidations) != 1 || info.DomainValidations[0].DomainName != "www.example.com" || info.DomainValidations[0].ValidationStatus != "SUCCESS" || info.DomainValidations[0].ValidationMethod != "DNS" {
+		t.Errorf("Correlate() DomainValidations = %+v, want one SUCCESS/DNS entry for www.example.com", info.DomainValidations)
+	}
+}
+
+func TestCorrelate_DescribeCertificateError(t *testing.T) {
+	vc := &cftypes.ViewerCertificate{ACMCertificateArn: aws.String("arn:aws:acm:us-east-1:111122223333:certificate/abc")}
+	client := &fakeACM{err: errors.New("throttled")}
+
+	if _, err := Correlate(context.Background(), client, vc, nil); err == nil {
+		t.Errorf("Correlate() error = nil, want the DescribeCertificate error to propagate")
+	}
+}
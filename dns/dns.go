@@ -0,0 +1,150 @@
+// Package dns reconciles a distribution's CloudFront aliases against Route53,
+// catching the common drift case where an alias is configured on a
+// distribution but DNS was never cut over or was moved elsewhere.
+package dns
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/route53"
+	"github.com/aws/aws-sdk-go-v2/service/route53/types"
+)
+
+// Well-known CloudFront hosted zone IDs used as the AliasTarget.HostedZoneId
+// on an alias record that points at a CloudFront distribution.
+// See: https://docs.aws.amazon.com/general/latest/gr/cf_region.html
+const (
+	CloudFrontHostedZoneIDStandard = "Z2FDTNDATAQYW2"
+	CloudFrontHostedZoneIDChina    = "Z3RFFRIM2A3IF5"
+)
+
+// Status is the outcome of reconciling one alias against Route53.
+type Status string
+
+const (
+	// StatusOK means an A/AAAA alias record exists and points at this
+	// distribution's domain name.
+	StatusOK Status = "ok"
+	// StatusMismatched means an A/AAAA alias record exists but points at a
+	// different CloudFront distribution (or another alias target entirely).
+	StatusMismatched Status = "mismatched"
+	// StatusOrphanCNAME means a plain CNAME record exists instead of an
+	// alias record - DNS was cut over to CloudFront with the wrong record
+	// type, or never migrated off an older target.
+	StatusOrphanCNAME Status = "orphan_cname"
+	// StatusMissing means no hosted zone, or no A/AAAA alias record or CNAME
+	// record, could be found for the alias. A plain (non-alias) A/AAAA record
+	// is reported as missing too.
+	StatusMissing Status = "missing"
+)
+
+// Result is the reconciliation outcome for a single alias.
+type Result struct {
+	Alias  string
+	Status Status
+	// Target is the record's current target (AliasTarget.DNSName or a CNAME
+	// value), empty when Status is StatusMissing.
+	Target string
+}
+
+// route53API is the subset of *route53.Client that Reconcile depends on,
+// narrowed so tests can exercise the reconciliation logic against a fake
+// instead of a live Route53 account.
+type route53API interface {
+	ListHostedZonesByName(ctx context.Context, params *route53.ListHostedZonesByNameInput, optFns ...func(*route53.Options)) (*route53.ListHostedZonesByNameOutput, error)
+	ListResourceRecordSets(ctx context.Context, params *route53.ListResourceRecordSetsInput, optFns ...func(*route53.Options)) (*route53.ListResourceRecordSetsOutput, error)
+}
+
+// Reconcile looks up alias in Route53 and compares it against
+// distributionDomainName, the CloudFront domain name of the distribution the
+// alias is configured on.
+func Reconcile(ctx context.Context, r53Client route53API, alias, distributionDomainName string) (Result, error) {
+	zoneID, err := findHostedZoneID(ctx, r53Client, alias)
+	if err != nil {
+		return Result{}, fmt.Errorf("dns: failed to find hosted zone for %s: %w", alias, err)
+	}
+	if zoneID == "" {
+		return Result{Alias: alias, Status: StatusMissing}, nil
+	}
+
+	recordSetsOutput, err := r53Client.ListResourceRecordSets(ctx, &route53.ListResourceRecordSetsInput{
+		HostedZoneId:    aws.String(zoneID),
+		StartRecordName: aws.String(alias),
+		MaxItems:        aws.Int32(10),
+	})
+	if err != nil {
+		return Result{}, fmt.Errorf("dns: failed to list record sets in zone %s: %w", zoneID, err)
+	}
+
+	aliasFQDN := normalizeName(alias)
+
+	for _, rrs := range recordSetsOutput.ResourceRecordSets {
+		if normalizeName(aws.ToString(rrs.Name)) != aliasFQDN {
+			continue
+		}
+
+		switch rrs.Type {
+		case types.RRTypeA, types.RRTypeAaaa:
+			if rrs.AliasTarget == nil {
+				continue
+			}
+			target := normalizeName(aws.ToString(rrs.AliasTarget.DNSName))
+			if !isCloudFrontHostedZone(aws.ToString(rrs.AliasTarget.HostedZoneId)) {
+				return Result{Alias: alias, Status: StatusMismatched, Target: target}, nil
+			}
+			if target == normalizeName(distributionDomainName) {
+				return Result{Alias: alias, Status: StatusOK, Target: target}, nil
+			}
+			return Result{Alias: alias, Status: StatusMismatched, Target: target}, nil
+		case types.RRTypeCname:
+			target := ""
+			if len(rrs.ResourceRecords) > 0 {
+				target = aws.ToString(rrs.ResourceRecords[0].Value)
+			}
+			return Result{Alias: alias, Status: StatusOrphanCNAME, Target: target}, nil
+		}
+	}
+
+	return Result{Alias: alias, Status: StatusMissing}, nil
+}
+
+// findHostedZoneID returns the ID of the most specific public hosted zone
+// that could contain alias, or "" if none is found. It walks from the full
+// name down to the apex, a label at a time, since alias itself need not be a
+// zone's own name.
+func findHostedZoneID(ctx context.Context, r53Client route53API, alias string) (string, error) {
+	labels := strings.Split(strings.TrimSuffix(alias, "."), ".")
+
+	for i := 0; i < len(labels)-1; i++ {
+		candidate := strings.Join(labels[i:], ".")
+
+		output, err := r53Client.ListHostedZonesByName(ctx, &route53.ListHostedZonesByNameInput{
+			DNSName: aws.String(candidate),
+		})
+		if err != nil {
+			return "", err
+		}
+
+		for _, zone := range output.HostedZones {
+			if zone.Config != nil && zone.Config.PrivateZone {
+				continue
+			}
+			if normalizeName(aws.ToString(zone.Name)) == normalizeName(candidate) {
+				return strings.TrimPrefix(aws.ToString(zone.Id), "/hostedzone/"), nil
+			}
+		}
+	}
+
+	return "", nil
+}
+
+func isCloudFrontHostedZone(hostedZoneID string) bool {
+	return hostedZoneID == CloudFrontHostedZoneIDStandard || hostedZoneID == CloudFrontHostedZoneIDChina
+}
+
+func normalizeName(name string) string {
+	return strings.ToLower(strings.TrimSuffix(name, "."))
+}
@@ -0,0 +1,219 @@
+package dns
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/route53"
+	"github.com/aws/aws-sdk-go-v2/service/route53/types"
+)
+
+// fakeRoute53 is a test double for route53API. zones is keyed by the DNSName
+// passed to ListHostedZonesByName; records is keyed by hosted zone ID.
+type fakeRoute53 struct {
+	zones          map[string][]types.HostedZone
+	records        map[string][]types.ResourceRecordSet
+	listZonesErr   error
+	listRecordsErr error
+}
+
+func (f *fakeRoute53) ListHostedZonesByName(_ context.Context, params *route53.ListHostedZonesByNameInput, _ ...func(*route53.Options)) (*route53.ListHostedZonesByNameOutput, error) {
+	if f.listZonesErr != nil {
+		return nil, f.listZonesErr
+	}
+	return &route53.ListHostedZonesByNameOutput{HostedZones: f.zones[aws.ToString(params.DNSName)]}, nil
+}
+
+func (f *fakeRoute53) ListResourceRecordSets(_ context.Context, params *route53.ListResourceRecordSetsInput, _ ...func(*route53.Options)) (*route53.ListResourceRecordSetsOutput, error) {
+	if f.listRecordsErr != nil {
+		return nil, f.listRecordsErr
+	}
+	return &route53.ListResourceRecordSetsOutput{ResourceRecordSets: f.records[aws.ToString(params.HostedZoneId)]}, nil
+}
+
+func publicZone(id, name string) types.HostedZone {
+	return types.HostedZone{Id: aws.String(id), Name: aws.String(name), Config: &types.HostedZoneConfig{}}
+}
+
+func aliasRecordSet(name, targetDNSName, targetHostedZoneID string) types.ResourceRecordSet {
+	return types.ResourceRecordSet{
+		Name: aws.String(name),
+		Type: types.RRTypeA,
+		AliasTarget: &types.AliasTarget{
+			DNSName:      aws.String(targetDNSName),
+			HostedZoneId: aws.String(targetHostedZoneID),
+		},
+	}
+}
+
+func cnameRecordSet(name, target string) types.ResourceRecordSet {
+	return types.ResourceRecordSet{
+		Name:            aws.String(name),
+		Type:            types.RRTypeCname,
+		ResourceRecords: []types.ResourceRecord{{Value: aws.String(target)}},
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	const (
+		zoneID       = "Z1EXAMPLE"
+		distDomain   = "d123.cloudfront.net"
+		otherDistCF  = "d999.cloudfront.net"
+		nonCFZoneID  = "Z2NOTCLOUDFRONT"
+		alias        = "www.example.com"
+		apexZoneName = "example.com."
+	)
+
+	tests := []struct {
+		name    string
+		fake    *fakeRoute53
+		want    Result
+		wantErr bool
+	}{
+		{
+			name: "alias record points at the distribution",
+			fake: &fakeRoute53{
+				zones: map[string][]types.HostedZone{
+					"example.com": {publicZone("/hostedzone/"+zoneID, apexZoneName)},
+				},
+				records: map[string][]types.ResourceRecordSet{
+					zoneID: {aliasRecordSet("www.example.com.", distDomain, CloudFrontHostedZoneIDStandard)},
+				},
+			},
+			want: Result{Alias: alias, Status: StatusOK, Target: "d123.cloudfront.net"},
+		},
+		{
+			name: "alias record points at a different distribution",
+			fake: &fakeRoute53{
+				zones: map[string][]types.HostedZone{
+					"example.com": {publicZone("/hostedzone/"+zoneID, apexZoneName)},
+				},
+				records: map[string][]types.ResourceRecordSet{
+					zoneID: {aliasRecordSet("www.example.com.", otherDistCF, CloudFrontHostedZoneIDStandard)},
+				},
+			},
+			want: Result{Alias: alias, Status: StatusMismatched, Target: "d999.cloudfront.net"},
+		},
+		{
+			name: "alias record points outside CloudFront entirely",
+			fake: &fakeRoute53{
+				zones: map[string][]types.HostedZone{
+					"example.com": {publicZone("/hostedzone/"+zoneID, apexZoneName)},
+				},
+				records: map[string][]types.ResourceRecordSet{
+					zoneID: {aliasRecordSet("www.example.com.", "elb.us-east-1.amazonaws.com", nonCFZoneID)},
+				},
+			},
+			want: Result{Alias: alias, Status: StatusMismatched, Target: "elb.us-east-1.amazonaws.com"},
+		},
+		{
+			name: "orphan CNAME instead of an alias record",
+			fake: &fakeRoute53{
+				zones: map[string][]types.HostedZone{
+					"example.com": {publicZone("/hostedzone/"+zoneID, apexZoneName)},
+				},
+				records: map[string][]types.ResourceRecordSet{
+					zoneID: {cnameRecordSet("www.example.com.", "legacy.example.net")},
+				},
+			},
+			want: Result{Alias: alias, Status: StatusOrphanCNAME, Target: "legacy.example.net"},
+		},
+		{
+			name: "no hosted zone found at all",
+			fake: &fakeRoute53{
+				zones: map[string][]types.HostedZone{},
+			},
+			want: Result{Alias: alias, Status: StatusMissing},
+		},
+		{
+			name: "hosted zone found but no matching record set",
+			fake: &fakeRoute53{
+				zones: map[string][]types.HostedZone{
+					"example.com": {publicZone("/hostedzone/"+zoneID, apexZoneName)},
+				},
+				records: map[string][]types.ResourceRecordSet{},
+			},
+			want: Result{Alias: alias, Status: StatusMissing},
+		},
+		{
+			name: "private hosted zone is skipped in favor of no match",
+			fake: &fakeRoute53{
+				zones: map[string][]types.HostedZone{
+					"example.com": {
+						{Id: aws.String("/hostedzone/" + zoneID), Name: aws.String(apexZoneName), Config: &types.HostedZoneConfig{PrivateZone: true}},
+					},
+				},
+			},
+			want: Result{Alias: alias, Status: StatusMissing},
+		},
+		{
+			name: "ListHostedZonesByName error propagates",
+			fake: &fakeRoute53{
+				listZonesErr: errors.New("throttled"),
+			},
+			wantErr: true,
+		},
+		{
+			name: "ListResourceRecordSets error propagates",
+			fake: &fakeRoute53{
+				zones: map[string][]types.HostedZone{
+					"example.com": {publicZone("/hostedzone/"+zoneID, apexZoneName)},
+				},
+				listRecordsErr: errors.New("access denied"),
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Reconcile(context.Background(), tt.fake, alias, distDomain)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Reconcile() error = nil, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Reconcile() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Reconcile() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindHostedZoneID_WalksUpToTheApex(t *testing.T) {
+	const zoneID = "Z1EXAMPLE"
+	fake := &fakeRoute53{
+		zones: map[string][]types.HostedZone{
+			// No zone registered for "a.b.example.com." or "b.example.com.";
+			// only the apex "example.com." exists, so the walk must climb
+			// past the subdomain labels to find it.
+			"example.com": {publicZone("/hostedzone/"+zoneID, "example.com.")},
+		},
+	}
+
+	got, err := findHostedZoneID(context.Background(), fake, "a.b.example.com")
+	if err != nil {
+		t.Fatalf("findHostedZoneID() error = %v", err)
+	}
+	if got != zoneID {
+		t.Errorf("findHostedZoneID() = %q, want %q", got, zoneID)
+	}
+}
+
+func TestFindHostedZoneID_NoMatch(t *testing.T) {
+	fake := &fakeRoute53{zones: map[string][]types.HostedZone{}}
+
+	got, err := findHostedZoneID(context.Background(), fake, "www.example.com")
+	if err != nil {
+		t.Fatalf("findHostedZoneID() error = %v", err)
+	}
+	if got != "" {
+		t.Errorf("findHostedZoneID() = %q, want empty string", got)
+	}
+}
@@ -0,0 +1,360 @@
+// Package filter implements a small expression language for selecting
+// resources by tag, e.g. `stack-name=foo AND env in (prod,staging) AND NOT team=legacy`.
+//
+// An expression is parsed into an Expr tree and then split in two by Compile:
+// the part that the Resource Groups Tagging API can evaluate natively (a
+// top-level conjunction of equality/IN conditions, each becoming its own
+// TagFilter) and a remainder Expr that must be evaluated client-side against
+// the Tags returned for each resource.
+package filter
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	rgtTypes "github.com/aws/aws-sdk-go-v2/service/resourcegroupstaggingapi/types"
+)
+
+// Op identifies how a TagCond compares a tag value.
+type Op int
+
+const (
+	// OpEq matches a tag whose value equals Values[0].
+	OpEq Op = iota
+	// OpIn matches a tag whose value is any of Values.
+	OpIn
+	// OpRegex matches a tag whose value matches the regular expression in Values[0].
+	OpRegex
+)
+
+// TagCond is a single `key=value`, `key in (a,b,c)` or `key ~= pattern` predicate.
+type TagCond struct {
+	Key    string
+	Op     Op
+	Values []string
+}
+
+// Expr is a node in the filter AST. Concrete types are *TagCond, *AndExpr,
+// *OrExpr and *NotExpr.
+type Expr interface {
+	isExpr()
+}
+
+func (*TagCond) isExpr() {}
+func (*AndExpr) isExpr() {}
+func (*OrExpr) isExpr()  {}
+func (*NotExpr) isExpr() {}
+
+// AndExpr is the conjunction of two expressions.
+type AndExpr struct {
+	Left, Right Expr
+}
+
+// OrExpr is the disjunction of two expressions.
+type OrExpr struct {
+	Left, Right Expr
+}
+
+// NotExpr negates an expression.
+type NotExpr struct {
+	Inner Expr
+}
+
+// Parse parses a filter expression into an Expr tree.
+//
+// Grammar (lowest to highest precedence):
+//
+//	expr   := orExpr
+//	orExpr := andExpr (OR andExpr)*
+//	andExpr:= unary (AND unary)*
+//	unary  := NOT unary | primary
+//	primary:= KEY = VALUE | KEY in ( VALUE (, VALUE)* ) | KEY ~= VALUE | ( expr )
+func Parse(expression string) (Expr, error) {
+	p := &parser{tokens: tokenize(expression)}
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos != len(p.tokens) {
+		return nil, fmt.Errorf("filter: unexpected token %q", p.tokens[p.pos])
+	}
+	return expr, nil
+}
+
+type parser struct {
+	tokens []string
+	pos    int
+}
+
+func (p *parser) peek() string {
+	if p.pos >= len(p.tokens) {
+		return ""
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() string {
+	tok := p.peek()
+	p.pos++
+	return tok
+}
+
+func (p *parser) parseOr() (Expr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for strings.EqualFold(p.peek(), "OR") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &OrExpr{Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (Expr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for strings.EqualFold(p.peek(), "AND") {
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &AndExpr{Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseUnary() (Expr, error) {
+	if strings.EqualFold(p.peek(), "NOT") {
+		p.next()
+		inner, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &NotExpr{Inner: inner}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (Expr, error) {
+	if p.peek() == "(" {
+		p.next()
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ")" {
+			return nil, fmt.Errorf("filter: expected ')', got %q", p.peek())
+		}
+		p.next()
+		return expr, nil
+	}
+
+	key := p.next()
+	if key == "" {
+		return nil, fmt.Errorf("filter: expected a tag key")
+	}
+
+	switch op := p.next(); {
+	case op == "=":
+		value := p.next()
+		if value == "" {
+			return nil, fmt.Errorf("filter: expected a value after %q=", key)
+		}
+		return &TagCond{Key: key, Op: OpEq, Values: []string{value}}, nil
+	case op == "~=":
+		pattern := p.next()
+		if pattern == "" {
+			return nil, fmt.Errorf("filter: expected a regex after %q~=", key)
+		}
+		if _, err := regexp.Compile(pattern); err != nil {
+			return nil, fmt.Errorf("filter: invalid regex %q: %w", pattern, err)
+		}
+		return &TagCond{Key: key, Op: OpRegex, Values: []string{pattern}}, nil
+	case strings.EqualFold(op, "in"):
+		if p.next() != "(" {
+			return nil, fmt.Errorf("filter: expected '(' after %q in", key)
+		}
+		var values []string
+		for {
+			tok := p.next()
+			if tok == "" {
+				return nil, fmt.Errorf("filter: unterminated 'in (...)' for %q", key)
+			}
+			if tok != "," {
+				values = append(values, tok)
+			}
+			if p.peek() == ")" {
+				p.next()
+				break
+			}
+		}
+		return &TagCond{Key: key, Op: OpIn, Values: values}, nil
+	default:
+		return nil, fmt.Errorf("filter: expected '=', '~=' or 'in' after %q, got %q", key, op)
+	}
+}
+
+// tokenize splits a filter expression into tokens, treating `(`, `)` and `,`
+// as standalone tokens and keeping `key=value` pairs intact as separate
+// key/operator/value tokens. The value following a `~=` operator is the one
+// exception: it is read verbatim by readRegexPattern instead of being run
+// through this general-purpose splitting, since a regular expression is
+// free to contain `(`, `)`, `,` and `=` itself.
+func tokenize(expression string) []string {
+	var tokens []string
+	var cur strings.Builder
+
+	flush := func() {
+		if cur.Len() > 0 {
+			tokens = append(tokens, cur.String())
+			cur.Reset()
+		}
+	}
+
+	runes := []rune(expression)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r == ' ' || r == '\t' || r == '\n':
+			flush()
+		case r == '(' || r == ')' || r == ',':
+			flush()
+			tokens = append(tokens, string(r))
+		case r == '=':
+			flush()
+			tokens = append(tokens, "=")
+		case r == '~' && i+1 < len(runes) && runes[i+1] == '=':
+			flush()
+			tokens = append(tokens, "~=")
+			pattern, last := readRegexPattern(runes, i+2)
+			if pattern != "" {
+				tokens = append(tokens, pattern)
+			}
+			i = last
+		default:
+			cur.WriteRune(r)
+		}
+	}
+	flush()
+	return tokens
+}
+
+// readRegexPattern reads the value following a `~=` operator verbatim,
+// starting at start, and returns it along with the index of the last rune
+// consumed. Unlike the rest of tokenize, `(`, `)`, `,` and `=` are all kept
+// as part of the pattern here since they're valid regex syntax; the pattern
+// ends at the next whitespace (a backslash does not escape it), the end of
+// input, or a `)` that
+// closes an enclosing `(...)` group rather than one opened within the
+// pattern itself.
+func readRegexPattern(runes []rune, start int) (pattern string, last int) {
+	i := start
+	for i < len(runes) && (runes[i] == ' ' || runes[i] == '\t' || runes[i] == '\n') {
+		i++
+	}
+
+	var b strings.Builder
+	depth := 0
+	for ; i < len(runes); i++ {
+		switch r := runes[i]; {
+		case r == ' ' || r == '\t' || r == '\n':
+			return b.String(), i - 1
+		case r == '(':
+			depth++
+			b.WriteRune(r)
+		case r == ')':
+			if depth == 0 {
+				return b.String(), i - 1
+			}
+			depth--
+			b.WriteRune(r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String(), i - 1
+}
+
+// Compile splits expr into the TagFilters that the Resource Groups Tagging
+// API can evaluate natively and a remainder Expr that must be evaluated
+// client-side with Evaluate against the Tags of each returned resource.
+//
+// Only conditions joined by AND at the top level, that are plain equality or
+// IN comparisons, are pulled into TagFilters — each TagFilter is itself an
+// implicit AND, with its Values OR'd together, which matches OpEq/OpIn
+// semantics exactly. NOT, OR and regex conditions always stay in the
+// remainder. remainder is nil if nothing is left to check client-side.
+func Compile(expr Expr) (tagFilters []rgtTypes.TagFilter, remainder Expr) {
+	for _, cond := range topLevelAnds(expr) {
+		if tc, ok := cond.(*TagCond); ok && (tc.Op == OpEq || tc.Op == OpIn) {
+			tagFilters = append(tagFilters, rgtTypes.TagFilter{
+				Key:    &tc.Key,
+				Values: append([]string(nil), tc.Values...),
+			})
+			continue
+		}
+		remainder = and(remainder, cond)
+	}
+	return tagFilters, remainder
+}
+
+func topLevelAnds(expr Expr) []Expr {
+	and, ok := expr.(*AndExpr)
+	if !ok {
+		return []Expr{expr}
+	}
+	return append(topLevelAnds(and.Left), topLevelAnds(and.Right)...)
+}
+
+func and(a, b Expr) Expr {
+	if a == nil {
+		return b
+	}
+	return &AndExpr{Left: a, Right: b}
+}
+
+// Evaluate reports whether the given tags satisfy expr. Tag keys are matched
+// case-sensitively; a key absent from tags never matches.
+func Evaluate(expr Expr, tags map[string]string) bool {
+	switch e := expr.(type) {
+	case nil:
+		return true
+	case *TagCond:
+		value, ok := tags[e.Key]
+		if !ok {
+			return false
+		}
+		switch e.Op {
+		case OpEq:
+			return value == e.Values[0]
+		case OpIn:
+			for _, v := range e.Values {
+				if value == v {
+					return true
+				}
+			}
+			return false
+		case OpRegex:
+			matched, err := regexp.MatchString(e.Values[0], value)
+			return err == nil && matched
+		}
+		return false
+	case *AndExpr:
+		return Evaluate(e.Left, tags) && Evaluate(e.Right, tags)
+	case *OrExpr:
+		return Evaluate(e.Left, tags) || Evaluate(e.Right, tags)
+	case *NotExpr:
+		return !Evaluate(e.Inner, tags)
+	default:
+		return false
+	}
+}
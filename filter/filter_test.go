@@ -0,0 +1,202 @@
+package filter
+
+import (
+	"testing"
+)
+
+func TestEvaluate_OperatorPrecedence(t *testing.T) {
+	// AND must bind tighter than OR: "a=1 OR b=2 AND c=3" is "a=1 OR (b=2 AND c=3)",
+	// so it should match when only a=1 holds, even though b/c don't.
+	expr, err := Parse("env=prod OR team=core AND cost-center=123")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	tags := map[string]string{"env": "prod"}
+	if !Evaluate(expr, tags) {
+		t.Errorf("Evaluate() = false, want true for tags %v (OR should short-circuit on env=prod)", tags)
+	}
+
+	tags = map[string]string{"team": "core"}
+	if Evaluate(expr, tags) {
+		t.Errorf("Evaluate() = true, want false for tags %v (AND branch needs both team and cost-center)", tags)
+	}
+}
+
+func TestEvaluate_NotAgainstAbsentKey(t *testing.T) {
+	// A condition on a key that isn't present never matches, so NOT-ing it is true.
+	expr, err := Parse("NOT team=legacy")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	if !Evaluate(expr, map[string]string{"env": "prod"}) {
+		t.Errorf("Evaluate() = false, want true when the key under NOT is absent from tags")
+	}
+	if Evaluate(expr, map[string]string{"team": "legacy"}) {
+		t.Errorf("Evaluate() = true, want false when the key under NOT is present and matches")
+	}
+}
+
+func TestParse_In(t *testing.T) {
+	expr, err := Parse("env in (prod, staging)")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	cond, ok := expr.(*TagCond)
+	if !ok {
+		t.Fatalf("Parse() = %T, want *TagCond", expr)
+	}
+	if cond.Key != "env" || cond.Op != OpIn {
+		t.Fatalf("Parse() = %+v, want Key=env Op=OpIn", cond)
+	}
+	if len(cond.Values) != 2 || cond.Values[0] != "prod" || cond.Values[1] != "staging" {
+		t.Fatalf("Parse() Values = %v, want [prod staging]", cond.Values)
+	}
+
+	if !Evaluate(expr, map[string]string{"env": "staging"}) {
+		t.Errorf("Evaluate() = false, want true for env=staging")
+	}
+	if Evaluate(expr, map[string]string{"env": "dev"}) {
+		t.Errorf("Evaluate() = true, want false for env=dev")
+	}
+}
+
+func TestParse_Regex(t *testing.T) {
+	expr, err := Parse(`name ~= ^prod-`)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	cond, ok := expr.(*TagCond)
+	if !ok || cond.Op != OpRegex {
+		t.Fatalf("Parse() = %+v, want a *TagCond with Op=OpRegex", expr)
+	}
+
+	if !Evaluate(expr, map[string]string{"name": "prod-east"}) {
+		t.Errorf("Evaluate() = false, want true for name=prod-east")
+	}
+	if Evaluate(expr, map[string]string{"name": "staging-east"}) {
+		t.Errorf("Evaluate() = true, want false for name=staging-east")
+	}
+
+	if _, err := Parse(`name ~= (unterminated`); err == nil {
+		t.Errorf("Parse() error = nil, want an error for an invalid regex")
+	}
+}
+
+func TestParse_RegexWithSpecialCharacters(t *testing.T) {
+	// Regression test: the tokenizer used to treat `(`, `)`, `,` and `=` as
+	// structural delimiters even inside a `~=` pattern, so any regex using
+	// grouping/alternation (the main reason to reach for ~= over in (...))
+	// failed to parse.
+	tests := []struct {
+		name    string
+		expr    string
+		pattern string
+		matches map[string]bool
+	}{
+		{
+			name:    "alternation inside a group",
+			expr:    "name ~= ^prod-(east|west)$",
+			pattern: "^prod-(east|west)$",
+			matches: map[string]bool{"prod-east": true, "prod-west": true, "prod-north": false},
+		},
+		{
+			name:    "comma in the pattern",
+			expr:    "name ~= a,b",
+			pattern: "a,b",
+			matches: map[string]bool{"a,b": true, "ab": false},
+		},
+		{
+			name:    "equals sign in the pattern",
+			expr:    "name ~= a=b",
+			pattern: "a=b",
+			matches: map[string]bool{"a=b": true, "ab": false},
+		},
+		{
+			name:    "grouped alternation nested inside a parenthesized expression",
+			expr:    "(name ~= ^prod-(east|west)$ AND env=prod)",
+			pattern: "^prod-(east|west)$",
+			matches: map[string]bool{"prod-east": true, "prod-north": false},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := Parse(tt.expr)
+			if err != nil {
+				t.Fatalf("Parse(%q) error = %v", tt.expr, err)
+			}
+
+			cond := expr
+			if and, ok := expr.(*AndExpr); ok {
+				cond = and.Left
+			}
+			tc, ok := cond.(*TagCond)
+			if !ok || tc.Op != OpRegex {
+				t.Fatalf("Parse(%q) = %+v, want a *TagCond with Op=OpRegex", tt.expr, expr)
+			}
+			if tc.Values[0] != tt.pattern {
+				t.Errorf("Parse(%q) pattern = %q, want %q", tt.expr, tc.Values[0], tt.pattern)
+			}
+
+			for name, want := range tt.matches {
+				if got := Evaluate(expr, map[string]string{"name": name, "env": "prod"}); got != want {
+					t.Errorf("Evaluate(%q, name=%q) = %v, want %v", tt.expr, name, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestCompile(t *testing.T) {
+	tests := []struct {
+		name             string
+		expression       string
+		wantTagFilters   int
+		wantHasRemainder bool
+	}{
+		{
+			name:           "pure conjunction of eq and in becomes TagFilters only",
+			expression:     "stack-name=foo AND env in (prod,staging)",
+			wantTagFilters: 2,
+		},
+		{
+			name:             "NOT stays in the remainder",
+			expression:       "stack-name=foo AND NOT team=legacy",
+			wantTagFilters:   1,
+			wantHasRemainder: true,
+		},
+		{
+			name:             "top-level OR stays entirely in the remainder",
+			expression:       "stack-name=foo OR stack-name=bar",
+			wantTagFilters:   0,
+			wantHasRemainder: true,
+		},
+		{
+			name:             "regex condition stays in the remainder",
+			expression:       `stack-name=foo AND name ~= ^prod-`,
+			wantTagFilters:   1,
+			wantHasRemainder: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := Parse(tt.expression)
+			if err != nil {
+				t.Fatalf("Parse(%q) error = %v", tt.expression, err)
+			}
+
+			tagFilters, remainder := Compile(expr)
+			if len(tagFilters) != tt.wantTagFilters {
+				t.Errorf("Compile(%q) tagFilters = %d, want %d", tt.expression, len(tagFilters), tt.wantTagFilters)
+			}
+			if (remainder != nil) != tt.wantHasRemainder {
+				t.Errorf("Compile(%q) remainder present = %v, want %v", tt.expression, remainder != nil, tt.wantHasRemainder)
+			}
+		})
+	}
+}
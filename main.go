@@ -2,104 +2,492 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
+	"os"
 	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/acm"
 	cf "github.com/aws/aws-sdk-go-v2/service/cloudfront"
+	cftypes "github.com/aws/aws-sdk-go-v2/service/cloudfront/types"
 	rgt "github.com/aws/aws-sdk-go-v2/service/resourcegroupstaggingapi"
 	rgtTypes "github.com/aws/aws-sdk-go-v2/service/resourcegroupstaggingapi/types"
+	"github.com/aws/aws-sdk-go-v2/service/route53"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	smithy "github.com/aws/smithy-go"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/arielschiavoni/cloudfront-domain-inspector/accounts"
+	"github.com/arielschiavoni/cloudfront-domain-inspector/cert"
+	"github.com/arielschiavoni/cloudfront-domain-inspector/dns"
+	"github.com/arielschiavoni/cloudfront-domain-inspector/filter"
+	"github.com/arielschiavoni/cloudfront-domain-inspector/output"
 )
 
+// maxGetDistributionRetries bounds the adaptive backoff applied when
+// CloudFront throttles GetDistribution calls.
+const maxGetDistributionRetries = 5
+
+// cloudFrontRegion is pinned because CloudFront is a global service; other
+// services fanned out across accounts in the future may need a per-region
+// loop instead.
+const cloudFrontRegion = "us-east-1"
+
+// candidate is a CloudFront distribution resource that passed the tag filter
+// and is queued for a GetDistribution call.
+type candidate struct {
+	distributionID string
+	arn            string
+	accountID      string
+	tags           map[string]string
+}
+
+// distributionResult is the outcome of fetching a single candidate's
+// distribution, collected so results can be printed in a stable order
+// regardless of which worker finished first.
+type distributionResult struct {
+	candidate candidate
+	dist      *cf.GetDistributionOutput
+	certInfo  *cert.Info
+	err       error
+}
+
 func main() {
-	// Define a command-line flag for the stack name.
-	stackName := flag.String("stack-name", "", "The stack name to filter resources")
+	// Define a command-line flag for the filter expression, e.g.
+	// -filter "stack-name=foo AND env in (prod,staging) AND NOT team=legacy".
+	filterExpr := flag.String("filter", "", `Tag filter expression, e.g. "stack-name=foo AND env in (prod,staging)"`)
+	concurrency := flag.Int("concurrency", 10, "Number of concurrent GetDistribution calls")
+	outputFormat := flag.String("output", "text", "Output format: text, json, csv or table")
+	withCNAMEs := flag.Bool("with-cnames", false, "Include each distribution's CloudFront domain name in the output")
+	accountsFile := flag.String("accounts", "", "Path to a YAML file listing AWS accounts and a role to assume in each, for an org-wide scan")
+	checkDNS := flag.Bool("check-dns", false, "Reconcile each alias against Route53 and report orphaned or mismatched DNS records")
 	flag.Parse()
 
-	if *stackName == "" {
-		log.Fatalf("You must specify a stack name using the -stack-name flag.")
+	if *filterExpr == "" {
+		log.Fatalf("You must specify a filter expression using the -filter flag.")
+	}
+	if *concurrency < 1 {
+		log.Fatalf("-concurrency must be at least 1, got %d", *concurrency)
+	}
+
+	writer, err := output.NewWriter(*outputFormat, os.Stdout)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+
+	expr, err := filter.Parse(*filterExpr)
+	if err != nil {
+		log.Fatalf("invalid -filter expression: %v", err)
 	}
 
+	// Pieces the Resource Groups Tagging API can express natively become
+	// TagFilters; anything left over (NOT, cross-key OR, regex) is evaluated
+	// client-side against each resource's Tags below.
+	tagFilters, remainder := filter.Compile(expr)
+
 	ctx := context.Background()
 
-	// Load the AWS configuration using the default options.
-	cfg, err := config.LoadDefaultConfig(ctx)
+	baseCfg, err := config.LoadDefaultConfig(ctx, config.WithRegion(cloudFrontRegion))
 	if err != nil {
 		log.Fatalf("failed to load configuration: %v", err)
 	}
 
-	// Create clients for the Resource Groups Tagging API and CloudFront.
-	tagClient := rgt.NewFromConfig(cfg)
-	cfClient := cf.NewFromConfig(cfg)
+	var records []output.Record
 
-	// Log and send the GetResources request to fetch resources with the specified tag,
-	// filtering for CloudFront distributions.
-	log.Printf("Sending GetResources request to fetch resources with tag stack-name=%s", *stackName)
-	resourcesOutput, err := tagClient.GetResources(ctx, &rgt.GetResourcesInput{
-		TagFilters: []rgtTypes.TagFilter{
-			{
-				Key:    aws.String("stack-name"),
-				Values: []string{*stackName},
-			},
-		},
-		ResourceTypeFilters: []string{"cloudfront:distribution"},
-	})
-	if err != nil {
-		log.Fatalf("failed to get resources by tag: %v", err)
+	if *accountsFile != "" {
+		accts, err := accounts.Load(*accountsFile)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+
+		for _, acct := range accts {
+			log.Printf("Scanning account %s (role %s)", acct.ID, acct.Role)
+			acctCfg, err := assumeRoleConfig(ctx, baseCfg, acct)
+			if err != nil {
+				log.Printf("failed to assume role in account %s: %v", acct.ID, err)
+				continue
+			}
+
+			acctRecords, err := scanAccount(ctx, acctCfg, *filterExpr, tagFilters, remainder, *concurrency, *withCNAMEs, *checkDNS)
+			if err != nil {
+				log.Printf("failed to scan account %s: %v", acct.ID, err)
+				continue
+			}
+			records = append(records, acctRecords...)
+		}
+	} else {
+		records, err = scanAccount(ctx, baseCfg, *filterExpr, tagFilters, remainder, *concurrency, *withCNAMEs, *checkDNS)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
 	}
 
-	if len(resourcesOutput.ResourceTagMappingList) == 0 {
-		fmt.Println("No resources found with the specified tag.")
+	if len(records) == 0 {
+		fmt.Println("No resources found matching the filter.")
 		return
 	}
 
-	log.Printf("Found %d distributions for stack-name=%s", len(resourcesOutput.ResourceTagMappingList), *stackName)
+	if err := writer.Write(records); err != nil {
+		log.Fatalf("failed to write output: %v", err)
+	}
+
+	if *outputFormat == "text" {
+		totalDomains := 0
+		for _, r := range records {
+			totalDomains += len(r.Aliases)
+		}
+		fmt.Printf("Total SAN domains found: %d\n", totalDomains)
+	}
+}
+
+// assumeRoleConfig builds an aws.Config scoped to the given account by
+// assuming acct.Role in it. Partition and region come from the account entry
+// itself (defaulting to the standard "aws" partition and cloudFrontRegion),
+// so accounts in another partition, e.g. aws-cn, can be scanned by setting
+// partition/region in the -accounts file rather than hardcoding them here.
+func assumeRoleConfig(ctx context.Context, baseCfg aws.Config, acct accounts.Account) (aws.Config, error) {
+	partition := acct.Partition
+	if partition == "" {
+		partition = "aws"
+	}
+	region := acct.Region
+	if region == "" {
+		region = cloudFrontRegion
+	}
+
+	roleARN := fmt.Sprintf("arn:%s:iam::%s:role/%s", partition, acct.ID, acct.Role)
+	stsClient := sts.NewFromConfig(baseCfg, func(o *sts.Options) {
+		o.Region = region
+	})
+	provider := stscreds.NewAssumeRoleProvider(stsClient, roleARN)
+
+	return config.LoadDefaultConfig(ctx,
+		config.WithRegion(region),
+		config.WithCredentialsProvider(aws.NewCredentialsCache(provider)),
+	)
+}
+
+// scanAccount runs the full tag-filter -> fetch -> record pipeline against a
+// single AWS account, identified by cfg.
+func scanAccount(ctx context.Context, cfg aws.Config, filterExpr string, tagFilters []rgtTypes.TagFilter, remainder filter.Expr, concurrency int, withCNAMEs, checkDNS bool) ([]output.Record, error) {
+	tagClient := rgt.NewFromConfig(cfg)
+	cfClient := cf.NewFromConfig(cfg)
+	acmClient := acm.NewFromConfig(cfg)
+	r53Client := route53.NewFromConfig(cfg)
+
+	candidates, err := collectCandidates(ctx, tagClient, tagFilters, remainder, filterExpr)
+	if err != nil {
+		return nil, err
+	}
+	if len(candidates) == 0 {
+		return nil, nil
+	}
 
-	totalDomains := 0
+	results := fetchDistributions(ctx, cfClient, acmClient, candidates, concurrency)
 
-	// Process each resource that is of type CloudFront distribution.
-	for _, resourceMapping := range resourcesOutput.ResourceTagMappingList {
-		if resourceMapping.ResourceARN == nil {
+	var records []output.Record
+	for _, result := range results {
+		if result.err != nil {
+			log.Printf("failed to get distribution %s: %v", result.candidate.distributionID, result.err)
 			continue
 		}
+		record := toRecord(result, withCNAMEs)
+		if checkDNS {
+			record.DNSChecks = reconcileDNS(ctx, r53Client, record.Aliases, aws.ToString(result.dist.Distribution.DomainName))
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
 
-		arn := *resourceMapping.ResourceARN
-		// Expecting ARN format: arn:aws:cloudfront::<account-id>:distribution/<distribution-id>
-		parts := strings.Split(arn, "/")
-		if len(parts) < 2 {
-			log.Printf("unexpected ARN format: %s", arn)
+// reconcileDNS checks every alias against Route53 and reports whether it has
+// a matching CloudFront alias record, points elsewhere, is an orphan CNAME,
+// or is missing entirely.
+func reconcileDNS(ctx context.Context, r53Client *route53.Client, aliases []string, distributionDomainName string) []output.DNSCheck {
+	checks := make([]output.DNSCheck, 0, len(aliases))
+	for _, alias := range aliases {
+		result, err := dns.Reconcile(ctx, r53Client, alias, distributionDomainName)
+		if err != nil {
+			log.Printf("failed to reconcile DNS for alias %s: %v", alias, err)
 			continue
 		}
-		distributionID := parts[len(parts)-1]
+		checks = append(checks, output.DNSCheck{
+			Alias:  result.Alias,
+			Status: string(result.Status),
+			Target: result.Target,
+		})
+	}
+	return checks
+}
+
+// toRecord converts a fetched distribution into the stable output schema.
+// The domain name is only populated when withCNAMEs is set, since it's the
+// one field callers opt into paying attention to.
+func toRecord(result distributionResult, withCNAMEs bool) output.Record {
+	dist := result.dist.Distribution
+	record := output.Record{
+		DistributionID: result.candidate.distributionID,
+		ARN:            result.candidate.arn,
+		AccountID:      result.candidate.accountID,
+		Tags:           result.candidate.tags,
+	}
+	if dist.Status != nil {
+		record.Status = *dist.Status
+	}
+	if dist.DistributionConfig != nil {
+		record.Enabled = aws.ToBool(dist.DistributionConfig.Enabled)
+		if dist.DistributionConfig.Aliases != nil {
+			record.Aliases = dist.DistributionConfig.Aliases.Items
+		}
+		if dist.DistributionConfig.Origins != nil {
+			for _, origin := range dist.DistributionConfig.Origins.Items {
+				record.Origins = append(record.Origins, output.Origin{
+					DomainName:     aws.ToString(origin.DomainName),
+					ProtocolPolicy: originProtocolPolicy(origin),
+				})
+			}
+		}
+	}
+	if withCNAMEs {
+		record.DomainName = aws.ToString(dist.DomainName)
+	}
+	if result.certInfo != nil {
+		var expiration *time.Time
+		if !result.certInfo.Expiration.IsZero() {
+			expiration = &result.certInfo.Expiration
+		}
+		record.Certificate = output.Certificate{
+			ARN:                     result.certInfo.ARN,
+			IAMCertificateID:        result.certInfo.IAMCertificateID,
+			Expiration:              expiration,
+			SubjectAlternativeNames: result.certInfo.SubjectAlternativeNames,
+			UncoveredAliases:        result.certInfo.UncoveredAliases,
+			DomainValidations:       toOutputDomainValidations(result.certInfo.DomainValidations),
+			Error:                   result.certInfo.Error,
+		}
+	}
+	return record
+}
 
-		log.Printf("Processing CloudFront distribution: %s (ARN: %s)", distributionID, arn)
+// toOutputDomainValidations converts cert.DomainValidation entries into the
+// output package's own type.
+func toOutputDomainValidations(validations []cert.DomainValidation) []output.DomainValidation {
+	out := make([]output.DomainValidation, 0, len(validations))
+	for _, dv := range validations {
+		out = append(out, output.DomainValidation{
+			DomainName:       dv.DomainName,
+			ValidationStatus: dv.ValidationStatus,
+			ValidationMethod: dv.ValidationMethod,
+		})
+	}
+	return out
+}
 
-		distConfigOutput, err := cfClient.GetDistributionConfig(ctx, &cf.GetDistributionConfigInput{
-			Id: aws.String(distributionID),
+// originProtocolPolicy returns the custom origin's protocol policy, or "s3"
+// for S3 origins, which don't have one.
+func originProtocolPolicy(origin cftypes.Origin) string {
+	if origin.CustomOriginConfig != nil {
+		return string(origin.CustomOriginConfig.OriginProtocolPolicy)
+	}
+	if origin.S3OriginConfig != nil {
+		return "s3"
+	}
+	return ""
+}
+
+// resourceGroupsAPI is the subset of *rgt.Client that collectCandidates
+// depends on, narrowed so tests can exercise the pagination loop against a
+// fake instead of a live Resource Groups Tagging API account.
+type resourceGroupsAPI interface {
+	GetResources(ctx context.Context, params *rgt.GetResourcesInput, optFns ...func(*rgt.Options)) (*rgt.GetResourcesOutput, error)
+}
+
+// collectCandidates drains every page of GetResources, evaluates the
+// client-side filter remainder against each resource's Tags, and returns the
+// distributions that passed.
+func collectCandidates(ctx context.Context, tagClient resourceGroupsAPI, tagFilters []rgtTypes.TagFilter, remainder filter.Expr, filterExpr string) ([]candidate, error) {
+	var candidates []candidate
+	var paginationToken *string
+	total := 0
+
+	for {
+		log.Printf("Sending GetResources request with filter=%q", filterExpr)
+		resourcesOutput, err := tagClient.GetResources(ctx, &rgt.GetResourcesInput{
+			TagFilters:          tagFilters,
+			ResourceTypeFilters: []string{"cloudfront:distribution"},
+			PaginationToken:     paginationToken,
 		})
 		if err != nil {
-			log.Printf("failed to get configuration for distribution %s: %v", distributionID, err)
-			continue
+			return nil, fmt.Errorf("failed to get resources by tag: %w", err)
 		}
 
-		// Print the distribution and its SAN domains (if any).
-		if distConfigOutput.DistributionConfig.Aliases != nil &&
-			len(distConfigOutput.DistributionConfig.Aliases.Items) > 0 {
-			fmt.Printf("Distribution ID: %s\n", distributionID)
-			fmt.Println("SAN domains:")
-			for _, alias := range distConfigOutput.DistributionConfig.Aliases.Items {
-				fmt.Printf(" - %s\n", alias)
-				totalDomains++
+		total += len(resourcesOutput.ResourceTagMappingList)
+
+		for _, resourceMapping := range resourcesOutput.ResourceTagMappingList {
+			if resourceMapping.ResourceARN == nil {
+				continue
+			}
+
+			tags := tagsToMap(resourceMapping.Tags)
+			if remainder != nil && !filter.Evaluate(remainder, tags) {
+				continue
+			}
+
+			arn := *resourceMapping.ResourceARN
+			// Expecting ARN format: arn:aws:cloudfront::<account-id>:distribution/<distribution-id>
+			arnParts := strings.Split(arn, ":")
+			accountID := ""
+			if len(arnParts) > 4 {
+				accountID = arnParts[4]
+			}
+			resourceParts := strings.Split(arn, "/")
+			if len(resourceParts) < 2 {
+				log.Printf("unexpected ARN format: %s", arn)
+				continue
+			}
+			candidates = append(candidates, candidate{
+				distributionID: resourceParts[len(resourceParts)-1],
+				arn:            arn,
+				accountID:      accountID,
+				tags:           tags,
+			})
+		}
+
+		if resourcesOutput.PaginationToken == nil || *resourcesOutput.PaginationToken == "" {
+			break
+		}
+		paginationToken = resourcesOutput.PaginationToken
+	}
+
+	log.Printf("Found %d distributions matching filter=%q before client-side evaluation", total, filterExpr)
+	return candidates, nil
+}
+
+// cfAPI is the subset of *cf.Client that fetchDistributions depends on,
+// narrowed so tests can exercise the worker pool and backoff logic against a
+// fake instead of a live CloudFront account.
+type cfAPI interface {
+	GetDistribution(ctx context.Context, params *cf.GetDistributionInput, optFns ...func(*cf.Options)) (*cf.GetDistributionOutput, error)
+}
+
+// fetchDistributions fans out GetDistribution calls across a worker pool of
+// the given size, retrying with adaptive backoff when CloudFront throttles
+// the caller. Results are returned in the same order as candidates.
+func fetchDistributions(ctx context.Context, cfClient cfAPI, acmClient *acm.Client, candidates []candidate, concurrency int) []distributionResult {
+	results := make([]distributionResult, len(candidates))
+
+	g, ctx := errgroup.WithContext(ctx)
+	sem := make(chan struct{}, concurrency)
+
+	for i, c := range candidates {
+		i, c := i, c
+		sem <- struct{}{}
+		g.Go(func() error {
+			defer func() { <-sem }()
+
+			log.Printf("Processing CloudFront distribution: %s (ARN: %s)", c.distributionID, c.arn)
+			dist, err := getDistributionWithBackoff(ctx, cfClient, c.distributionID)
+			if err != nil {
+				results[i] = distributionResult{candidate: c, err: err}
+				return nil
 			}
-			fmt.Println()
-		} else {
-			fmt.Printf("Distribution ID: %s has no SAN domains.\n\n", distributionID)
+
+			var aliases []string
+			var viewerCert *cftypes.ViewerCertificate
+			if config := dist.Distribution.DistributionConfig; config != nil {
+				if config.Aliases != nil {
+					aliases = config.Aliases.Items
+				}
+				viewerCert = config.ViewerCertificate
+			}
+			certInfo, err := cert.Correlate(ctx, acmClient, viewerCert, aliases)
+			if err != nil {
+				log.Printf("failed to correlate certificate for distribution %s: %v", c.distributionID, err)
+				certInfo = &cert.Info{Error: err.Error()}
+			}
+
+			results[i] = distributionResult{candidate: c, dist: dist, certInfo: certInfo}
+			return nil
+		})
+	}
+
+	// Errors are captured per-candidate in results rather than surfaced here:
+	// every worker func returns nil, so g.Wait() never returns an error.
+	_ = g.Wait()
+	return results
+}
+
+// getDistributionWithBackoff calls GetDistribution, retrying with exponential
+// backoff when CloudFront reports throttling.
+func getDistributionWithBackoff(ctx context.Context, cfClient cfAPI, distributionID string) (*cf.GetDistributionOutput, error) {
+	return getDistributionWithBackoffConfig(ctx, cfClient, distributionID, maxGetDistributionRetries, 200*time.Millisecond)
+}
+
+// getDistributionWithBackoffConfig is getDistributionWithBackoff with the
+// retry cap and initial backoff broken out, so tests can exercise the
+// retry/backoff logic without waiting on the real durations.
+func getDistributionWithBackoffConfig(ctx context.Context, cfClient cfAPI, distributionID string, maxRetries int, initialBackoff time.Duration) (*cf.GetDistributionOutput, error) {
+	backoff := initialBackoff
+
+	var lastErr error
+	for attempt := 0; attempt <= maxRetries; attempt++ {
+		output, err := cfClient.GetDistribution(ctx, &cf.GetDistributionInput{
+			Id: aws.String(distributionID),
+		})
+		if err == nil {
+			return output, nil
+		}
+		lastErr = err
+
+		if !isThrottlingError(err) || attempt == maxRetries {
+			return nil, err
 		}
+
+		log.Printf("distribution %s throttled (attempt %d/%d), backing off for %s", distributionID, attempt+1, maxRetries, backoff)
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		backoff *= 2
 	}
+	return nil, lastErr
+}
 
-	// Print the total amount of SAN domains found.
-	fmt.Printf("Total SAN domains found: %d\n", totalDomains)
+// isThrottlingError reports whether err is a CloudFront Throttling or
+// RequestLimitExceeded API error.
+func isThrottlingError(err error) bool {
+	var apiErr smithy.APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	switch apiErr.ErrorCode() {
+	case "Throttling", "RequestLimitExceeded":
+		return true
+	default:
+		return false
+	}
+}
+
+// tagsToMap converts the Tags slice on a ResourceTagMapping into a key/value
+// map for client-side filter evaluation and for the matched-tags output column.
+func tagsToMap(tags []rgtTypes.Tag) map[string]string {
+	m := make(map[string]string, len(tags))
+	for _, tag := range tags {
+		if tag.Key == nil {
+			continue
+		}
+		value := ""
+		if tag.Value != nil {
+			value = *tag.Value
+		}
+		m[*tag.Key] = value
+	}
+	return m
 }
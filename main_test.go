@@ -0,0 +1,281 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	cf "github.com/aws/aws-sdk-go-v2/service/cloudfront"
+	cftypes "github.com/aws/aws-sdk-go-v2/service/cloudfront/types"
+	rgt "github.com/aws/aws-sdk-go-v2/service/resourcegroupstaggingapi"
+	rgtTypes "github.com/aws/aws-sdk-go-v2/service/resourcegroupstaggingapi/types"
+	smithy "github.com/aws/smithy-go"
+)
+
+// fakeCF is a test double for cfAPI. It returns throttlingErrs in order on
+// successive calls, then succeeds (or returns finalErr, if set).
+type fakeCF struct {
+	throttlingErrs []error
+	finalErr       error
+	calls          int
+}
+
+func (f *fakeCF) GetDistribution(_ context.Context, params *cf.GetDistributionInput, _ ...func(*cf.Options)) (*cf.GetDistributionOutput, error) {
+	call := f.calls
+	f.calls++
+
+	if call < len(f.throttlingErrs) {
+		return nil, f.throttlingErrs[call]
+	}
+	if f.finalErr != nil {
+		return nil, f.finalErr
+	}
+	return &cf.GetDistributionOutput{Distribution: &cftypes.Distribution{DistributionConfig: &cftypes.DistributionConfig{}}}, nil
+}
+
+func throttled() error {
+	return &smithy.GenericAPIError{Code: "Throttling"}
+}
+
+func TestIsThrottlingError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{
+			name: "Throttling API error",
+			err:  &smithy.GenericAPIError{Code: "Throttling"},
+			want: true,
+		},
+		{
+			name: "RequestLimitExceeded API error",
+			err:  &smithy.GenericAPIError{Code: "RequestLimitExceeded"},
+			want: true,
+		},
+		{
+			name: "other API error code",
+			err:  &smithy.GenericAPIError{Code: "AccessDenied"},
+			want: false,
+		},
+		{
+			name: "non-API error",
+			err:  fmt.Errorf("connection reset by peer"),
+			want: false,
+		},
+		{
+			name: "API error wrapped with additional context",
+			err:  fmt.Errorf("failed to get distribution: %w", &smithy.GenericAPIError{Code: "Throttling"}),
+			want: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isThrottlingError(tt.err); got != tt.want {
+				t.Errorf("isThrottlingError(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+
+	if isThrottlingError(nil) {
+		t.Errorf("isThrottlingError(nil) = true, want false")
+	}
+}
+
+func TestGetDistributionWithBackoffConfig(t *testing.T) {
+	tests := []struct {
+		name       string
+		client     *fakeCF
+		maxRetries int
+		wantCalls  int
+		wantErr    bool
+	}{
+		{
+			name:       "succeeds on the first attempt",
+			client:     &fakeCF{},
+			maxRetries: 5,
+			wantCalls:  1,
+		},
+		{
+			name:       "retries through throttling and then succeeds",
+			client:     &fakeCF{throttlingErrs: []error{throttled(), throttled()}},
+			maxRetries: 5,
+			wantCalls:  3,
+		},
+		{
+			name:       "a non-throttling error short-circuits without retrying",
+			client:     &fakeCF{finalErr: &smithy.GenericAPIError{Code: "AccessDenied"}},
+			maxRetries: 5,
+			wantCalls:  1,
+			wantErr:    true,
+		},
+		{
+			name:       "throttling past the retry cap gives up",
+			client:     &fakeCF{throttlingErrs: []error{throttled(), throttled(), throttled()}},
+			maxRetries: 2,
+			wantCalls:  3,
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := getDistributionWithBackoffConfig(context.Background(), tt.client, "E123", tt.maxRetries, time.Millisecond)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("getDistributionWithBackoffConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.client.calls != tt.wantCalls {
+				t.Errorf("GetDistribution called %d times, want %d", tt.client.calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestGetDistributionWithBackoffConfig_BackoffGrows(t *testing.T) {
+	client := &fakeCF{throttlingErrs: []error{throttled(), throttled()}}
+
+	start := time.Now()
+	if _, err := getDistributionWithBackoffConfig(context.Background(), client, "E123", 5, 10*time.Millisecond); err != nil {
+		t.Fatalf("getDistributionWithBackoffConfig() error = %v", err)
+	}
+	elapsed := time.Since(start)
+
+	// Two throttled attempts with a 10ms initial backoff that doubles: 10ms + 20ms = 30ms.
+	if elapsed < 30*time.Millisecond {
+		t.Errorf("getDistributionWithBackoffConfig() took %s, want at least 30ms from the doubling backoff", elapsed)
+	}
+}
+
+func TestGetDistributionWithBackoffConfig_ContextCancelled(t *testing.T) {
+	client := &fakeCF{throttlingErrs: []error{throttled(), throttled()}}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := getDistributionWithBackoffConfig(ctx, client, "E123", 5, time.Millisecond); err == nil {
+		t.Errorf("getDistributionWithBackoffConfig() error = nil, want context.Canceled once the context is done")
+	}
+}
+
+// fakeCFByID is a test double for cfAPI keyed by distribution ID, safe for
+// concurrent use by fetchDistributions' worker pool. A missing entry fails
+// the test outright rather than silently succeeding.
+type fakeCFByID struct {
+	mu   sync.Mutex
+	errs map[string]error
+	t    *testing.T
+}
+
+func (f *fakeCFByID) GetDistribution(_ context.Context, params *cf.GetDistributionInput, _ ...func(*cf.Options)) (*cf.GetDistributionOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	id := *params.Id
+	err, ok := f.errs[id]
+	if !ok {
+		f.t.Fatalf("unexpected GetDistribution call for %q", id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &cf.GetDistributionOutput{Distribution: &cftypes.Distribution{DistributionConfig: &cftypes.DistributionConfig{}}}, nil
+}
+
+func TestFetchDistributions(t *testing.T) {
+	candidates := []candidate{
+		{distributionID: "E1", arn: "arn:aws:cloudfront::1:distribution/E1"},
+		{distributionID: "E2", arn: "arn:aws:cloudfront::1:distribution/E2"},
+		{distributionID: "E3", arn: "arn:aws:cloudfront::1:distribution/E3"},
+	}
+	client := &fakeCFByID{
+		t: t,
+		errs: map[string]error{
+			"E1": nil,
+			"E2": &smithy.GenericAPIError{Code: "AccessDenied"},
+			"E3": nil,
+		},
+	}
+
+	results := fetchDistributions(context.Background(), client, nil, candidates, 2)
+
+	if len(results) != len(candidates) {
+		t.Fatalf("fetchDistributions() returned %d results, want %d", len(results), len(candidates))
+	}
+	for i, c := range candidates {
+		if results[i].candidate.distributionID != c.distributionID {
+			t.Errorf("results[%d].candidate.distributionID = %q, want %q (results must stay in candidate order)", i, results[i].candidate.distributionID, c.distributionID)
+		}
+	}
+	if results[0].err != nil || results[0].dist == nil {
+		t.Errorf("results[0] = %+v, want a successful result for E1", results[0])
+	}
+	if results[1].err == nil {
+		t.Errorf("results[1].err = nil, want the AccessDenied error for E2")
+	}
+	if results[2].err != nil || results[2].dist == nil {
+		t.Errorf("results[2] = %+v, want a successful result for E3", results[2])
+	}
+}
+
+// fakeResourceGroups is a test double for resourceGroupsAPI. It returns one
+// page of pages per call, in order, paginating via PaginationToken exactly
+// like the real API.
+type fakeResourceGroups struct {
+	pages [][]rgtTypes.ResourceTagMapping
+	calls int
+}
+
+func (f *fakeResourceGroups) GetResources(_ context.Context, params *rgt.GetResourcesInput, _ ...func(*rgt.Options)) (*rgt.GetResourcesOutput, error) {
+	page := f.calls
+	f.calls++
+
+	output := &rgt.GetResourcesOutput{ResourceTagMappingList: f.pages[page]}
+	if page < len(f.pages)-1 {
+		output.PaginationToken = aws.String(fmt.Sprintf("page-%d", page+1))
+	}
+	return output, nil
+}
+
+func resourceMapping(arn string) rgtTypes.ResourceTagMapping {
+	return rgtTypes.ResourceTagMapping{ResourceARN: aws.String(arn)}
+}
+
+func TestCollectCandidates_DrainsAllPages(t *testing.T) {
+	client := &fakeResourceGroups{
+		pages: [][]rgtTypes.ResourceTagMapping{
+			{
+				resourceMapping("arn:aws:cloudfront::111122223333:distribution/E1"),
+				resourceMapping("arn:aws:cloudfront::111122223333:distribution/E2"),
+			},
+			{
+				resourceMapping("arn:aws:cloudfront::111122223333:distribution/E3"),
+			},
+		},
+	}
+
+	candidates, err := collectCandidates(context.Background(), client, nil, nil, "")
+	if err != nil {
+		t.Fatalf("collectCandidates() error = %v", err)
+	}
+
+	if client.calls != 2 {
+		t.Fatalf("GetResources called %d times, want 2 (one per page)", client.calls)
+	}
+
+	var gotIDs []string
+	for _, c := range candidates {
+		gotIDs = append(gotIDs, c.distributionID)
+	}
+	wantIDs := []string{"E1", "E2", "E3"}
+	if len(gotIDs) != len(wantIDs) {
+		t.Fatalf("collectCandidates() returned %v, want %v", gotIDs, wantIDs)
+	}
+	for i, want := range wantIDs {
+		if gotIDs[i] != want {
+			t.Errorf("candidates[%d].distributionID = %q, want %q", i, gotIDs[i], want)
+		}
+	}
+}
@@ -0,0 +1,249 @@
+// Package output renders the distributions discovered by the inspector in a
+// handful of interchangeable formats (text, json, csv, table), all driven
+// from the same stable Record schema.
+package output
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Origin is one of a distribution's origins.
+type Origin struct {
+	DomainName     string `json:"domain_name"`
+	ProtocolPolicy string `json:"protocol_policy"`
+}
+
+// DomainValidation is one entry of an ACM certificate's DomainValidationOptions.
+type DomainValidation struct {
+	DomainName       string `json:"domain_name"`
+	ValidationStatus string `json:"validation_status"`
+	ValidationMethod string `json:"validation_method"`
+}
+
+// Certificate summarizes the viewer certificate protecting a distribution
+// and how well it covers the distribution's aliases. Expiration is nil
+// and SubjectAlternativeNames/UncoveredAliases/DomainValidations are
+// empty for IAM-uploaded or CloudFront-default certificates, since ACM can't
+// describe those.
+type Certificate struct {
+	ARN                     string             `json:"arn,omitempty"`
+	IAMCertificateID        string             `json:"iam_certificate_id,omitempty"`
+	Expiration              *time.Time         `json:"expiration,omitempty"`
+	SubjectAlternativeNames []string           `json:"subject_alternative_names,omitempty"`
+	UncoveredAliases        []string           `json:"uncovered_aliases,omitempty"`
+	DomainValidations       []DomainValidation `json:"domain_validations,omitempty"`
+	// Error is set instead of the fields above when the certificate lookup
+	// itself failed (e.g. ACM DescribeCertificate was throttled or denied),
+	// so a transient API error doesn't render identically to "no certificate
+	// to flag" in every output format.
+	Error string `json:"error,omitempty"`
+}
+
+// DNSCheck is the Route53 reconciliation outcome for one alias, populated
+// when the caller passes -check-dns.
+type DNSCheck struct {
+	Alias  string `json:"alias"`
+	Status string `json:"status"`
+	Target string `json:"target"`
+}
+
+// Record is one CloudFront distribution in the report. DomainName is only
+// populated when the caller passes -with-cnames. DNSChecks is only populated
+// when the caller passes -check-dns.
+type Record struct {
+	DistributionID string            `json:"distribution_id"`
+	ARN            string            `json:"arn"`
+	AccountID      string            `json:"account_id"`
+	Enabled        bool              `json:"enabled"`
+	Status         string            `json:"status"`
+	Aliases        []string          `json:"aliases,omitempty"`
+	Tags           map[string]string `json:"tags,omitempty"`
+	DomainName     string            `json:"domain_name,omitempty"`
+	Origins        []Origin          `json:"origins,omitempty"`
+	Certificate    Certificate       `json:"certificate"`
+	DNSChecks      []DNSCheck        `json:"dns_checks,omitempty"`
+}
+
+// Writer renders a batch of Records to an underlying io.Writer.
+type Writer interface {
+	Write(records []Record) error
+}
+
+// NewWriter returns the Writer for the named format: "text", "json", "csv"
+// or "table". It returns an error for any other format.
+func NewWriter(format string, w io.Writer) (Writer, error) {
+	switch format {
+	case "text":
+		return &textWriter{w: w}, nil
+	case "json":
+		return &jsonWriter{w: w}, nil
+	case "csv":
+		return &csvWriter{w: w}, nil
+	case "table":
+		return &tableWriter{w: w}, nil
+	default:
+		return nil, fmt.Errorf("output: unknown format %q (want text, json, csv or table)", format)
+	}
+}
+
+type textWriter struct{ w io.Writer }
+
+func (t *textWriter) Write(records []Record) error {
+	for _, r := range records {
+		if len(r.Aliases) == 0 {
+			fmt.Fprintf(t.w, "Distribution ID: %s has no SAN domains.\n\n", r.DistributionID)
+			continue
+		}
+		fmt.Fprintf(t.w, "Distribution ID: %s\n", r.DistributionID)
+		fmt.Fprintln(t.w, "SAN domains:")
+		for _, alias := range r.Aliases {
+			fmt.Fprintf(t.w, " - %s\n", alias)
+		}
+		if cert := r.Certificate; cert.Error != "" {
+			fmt.Fprintf(t.w, "Certificate: ERROR: %s\n", cert.Error)
+		} else if cert.ARN != "" {
+			fmt.Fprintf(t.w, "Certificate: %s (expires %s)\n", cert.ARN, formatExpiration(cert.Expiration))
+			if len(cert.UncoveredAliases) > 0 {
+				fmt.Fprintf(t.w, "  WARNING: not covered by certificate: %s\n", strings.Join(cert.UncoveredAliases, ", "))
+			}
+			for _, dv := range cert.DomainValidations {
+				fmt.Fprintf(t.w, "  Domain validation: %s (%s via %s)\n", dv.DomainName, dv.ValidationStatus, dv.ValidationMethod)
+			}
+		} else if cert.IAMCertificateID != "" {
+			fmt.Fprintf(t.w, "Certificate: IAM certificate %s\n", cert.IAMCertificateID)
+		}
+		for _, origin := range r.Origins {
+			fmt.Fprintf(t.w, "Origin: %s (%s)\n", origin.DomainName, origin.ProtocolPolicy)
+		}
+		for _, check := range r.DNSChecks {
+			fmt.Fprintf(t.w, "DNS: %s -> %s (%s)\n", check.Alias, check.Target, check.Status)
+		}
+		fmt.Fprintln(t.w)
+	}
+	return nil
+}
+
+type jsonWriter struct{ w io.Writer }
+
+func (j *jsonWriter) Write(records []Record) error {
+	enc := json.NewEncoder(j.w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(records)
+}
+
+type csvWriter struct{ w io.Writer }
+
+func (c *csvWriter) Write(records []Record) error {
+	cw := csv.NewWriter(c.w)
+	header := []string{
+		"distribution_id", "arn", "account_id", "enabled", "status", "aliases", "tags", "domain_name",
+		"origins", "certificate_arn", "certificate_expiration", "certificate_error", "uncovered_aliases", "domain_validations", "dns_checks",
+	}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	for _, r := range records {
+		certARN := r.Certificate.ARN
+		if certARN == "" {
+			certARN = r.Certificate.IAMCertificateID
+		}
+		expiration := formatExpiration(r.Certificate.Expiration)
+		row := []string{
+			r.DistributionID,
+			r.ARN,
+			r.AccountID,
+			fmt.Sprintf("%t", r.Enabled),
+			r.Status,
+			strings.Join(r.Aliases, ";"),
+			joinTags(r.Tags),
+			r.DomainName,
+			joinOrigins(r.Origins),
+			certARN,
+			expiration,
+			r.Certificate.Error,
+			strings.Join(r.Certificate.UncoveredAliases, ";"),
+			joinDomainValidations(r.Certificate.DomainValidations),
+			joinDNSChecks(r.DNSChecks),
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+type tableWriter struct{ w io.Writer }
+
+func (t *tableWriter) Write(records []Record) error {
+	tw := tabwriter.NewWriter(t.w, 0, 2, 2, ' ', 0)
+	fmt.Fprintln(tw, "DISTRIBUTION ID\tACCOUNT ID\tENABLED\tSTATUS\tALIASES\tDOMAIN NAME\tCERT EXPIRES\tCERT ERROR\tUNCOVERED ALIASES\tDOMAIN VALIDATIONS\tDNS CHECKS")
+	for _, r := range records {
+		expiration := formatExpiration(r.Certificate.Expiration)
+		fmt.Fprintf(tw, "%s\t%s\t%t\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			r.DistributionID, r.AccountID, r.Enabled, r.Status, strings.Join(r.Aliases, ","), r.DomainName,
+			expiration, r.Certificate.Error, strings.Join(r.Certificate.UncoveredAliases, ","), joinDomainValidations(r.Certificate.DomainValidations), joinDNSChecks(r.DNSChecks))
+	}
+	return tw.Flush()
+}
+
+// formatExpiration renders a certificate expiration as YYYY-MM-DD, or "" if
+// unset (IAM-uploaded or CloudFront-default certificates have no ACM
+// expiration).
+func formatExpiration(expiration *time.Time) string {
+	if expiration == nil {
+		return ""
+	}
+	return expiration.Format("2006-01-02")
+}
+
+// joinOrigins renders origins as a "domain(policy)" list joined by ";".
+func joinOrigins(origins []Origin) string {
+	parts := make([]string, 0, len(origins))
+	for _, o := range origins {
+		parts = append(parts, fmt.Sprintf("%s(%s)", o.DomainName, o.ProtocolPolicy))
+	}
+	return strings.Join(parts, ";")
+}
+
+// joinDomainValidations renders domain validations as a "domain:status" list
+// joined by ";".
+func joinDomainValidations(validations []DomainValidation) string {
+	parts := make([]string, 0, len(validations))
+	for _, dv := range validations {
+		parts = append(parts, fmt.Sprintf("%s:%s", dv.DomainName, dv.ValidationStatus))
+	}
+	return strings.Join(parts, ";")
+}
+
+// joinDNSChecks renders DNS checks as an "alias:status" list joined by ";".
+func joinDNSChecks(checks []DNSCheck) string {
+	parts := make([]string, 0, len(checks))
+	for _, c := range checks {
+		parts = append(parts, fmt.Sprintf("%s:%s", c.Alias, c.Status))
+	}
+	return strings.Join(parts, ";")
+}
+
+// joinTags renders tags as a deterministically ordered "key=value" list
+// joined by ";" so CSV output is stable across runs.
+func joinTags(tags map[string]string) string {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, fmt.Sprintf("%s=%s", k, tags[k]))
+	}
+	return strings.Join(pairs, ";")
+}
@@ -0,0 +1,272 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewWriter(t *testing.T) {
+	tests := []struct {
+		format  string
+		wantErr bool
+	}{
+		{format: "text"},
+		{format: "json"},
+		{format: "csv"},
+		{format: "table"},
+		{format: "xml", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			w, err := NewWriter(tt.format, &bytes.Buffer{})
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("NewWriter(%q) error = nil, want an error", tt.format)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewWriter(%q) error = %v", tt.format, err)
+			}
+			if w == nil {
+				t.Fatalf("NewWriter(%q) = nil Writer", tt.format)
+			}
+		})
+	}
+}
+
+func sampleRecord() Record {
+	expiration := time.Date(2030, time.January, 1, 0, 0, 0, 0, time.UTC)
+	return Record{
+		DistributionID: "E123456",
+		ARN:            "arn:aws:cloudfront::111122223333:distribution/E123456",
+		AccountID:      "111122223333",
+		Enabled:        true,
+		Status:         "Deployed",
+		Aliases:        []string{"www.example.com"},
+		Tags:           map[string]string{"env": "prod"},
+		Origins: []Origin{
+			{DomainName: "origin.example.com", ProtocolPolicy: "https-only"},
+		},
+		Certificate: Certificate{
+			ARN:              "arn:aws:acm:us-east-1:111122223333:certificate/abc",
+			Expiration:       &expiration,
+			UncoveredAliases: []string{"other.example.com"},
+			DomainValidations: []DomainValidation{
+				{DomainName: "www.example.com", ValidationStatus: "SUCCESS", ValidationMethod: "DNS"},
+			},
+		},
+		DNSChecks: []DNSCheck{
+			{Alias: "www.example.com", Status: "ok", Target: "d123.cloudfront.net"},
+		},
+	}
+}
+
+func TestTextWriter_NoAliases(t *testing.T) {
+	var buf bytes.Buffer
+	w, _ := NewWriter("text", &buf)
+
+	if err := w.Write([]Record{{DistributionID: "E000000"}}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	if got := buf.String(); !strings.Contains(got, "E000000 has no SAN domains") {
+		t.Errorf("Write() = %q, want it to report no SAN domains", got)
+	}
+}
+
+func TestTextWriter_CertificateError(t *testing.T) {
+	var buf bytes.Buffer
+	w, _ := NewWriter("text", &buf)
+
+	record := sampleRecord()
+	record.Certificate = Certificate{Error: "throttled"}
+
+	if err := w.Write([]Record{record}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	got := buf.String()
+	if !strings.Contains(got, "Certificate: ERROR: throttled") {
+		t.Errorf("Write() = %q, want a certificate error line", got)
+	}
+	if strings.Contains(got, "expires") {
+		t.Errorf("Write() = %q, should not render expiration details for a failed lookup", got)
+	}
+}
+
+func TestTextWriter_IAMCertificate(t *testing.T) {
+	var buf bytes.Buffer
+	w, _ := NewWriter("text", &buf)
+
+	record := sampleRecord()
+	record.Certificate = Certificate{IAMCertificateID: "ASCAEXAMPLE"}
+
+	if err := w.Write([]Record{record}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	if got := buf.String(); !strings.Contains(got, "Certificate: IAM certificate ASCAEXAMPLE") {
+		t.Errorf("Write() = %q, want the IAM certificate ID reported", got)
+	}
+}
+
+func TestJSONWriter_UsesSnakeCaseSchema(t *testing.T) {
+	var buf bytes.Buffer
+	w, _ := NewWriter("json", &buf)
+
+	if err := w.Write([]Record{sampleRecord()}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	var decoded []map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("output is not valid JSON: %v", err)
+	}
+	if len(decoded) != 1 {
+		t.Fatalf("decoded %d records, want 1", len(decoded))
+	}
+
+	record := decoded[0]
+	for _, field := range []string{"distribution_id", "account_id", "certificate"} {
+		if _, ok := record[field]; !ok {
+			t.Errorf("JSON output missing field %q: %v", field, record)
+		}
+	}
+	if _, ok := record["DistributionID"]; ok {
+		t.Errorf("JSON output used the raw Go field name DistributionID instead of a json tag")
+	}
+}
+
+func TestJSONWriter_OmitsExpirationForZeroCertificate(t *testing.T) {
+	// Regression test: Expiration used to be a plain time.Time, so
+	// omitempty never applied and a distribution with no ACM certificate
+	// (IAM-uploaded or CloudFront-default) serialized a bogus
+	// "0001-01-01T00:00:00Z" expiration instead of omitting the field.
+	var buf bytes.Buffer
+	w, _ := NewWriter("json", &buf)
+
+	record := Record{DistributionID: "E000000", Certificate: Certificate{}}
+	if err := w.Write([]Record{record}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	var decoded []map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("output is not valid JSON: %v", err)
+	}
+
+	cert, ok := decoded[0]["certificate"].(map[string]any)
+	if !ok {
+		t.Fatalf("decoded certificate = %v, want a JSON object", decoded[0]["certificate"])
+	}
+	if _, ok := cert["expiration"]; ok {
+		t.Errorf("certificate JSON = %v, want no \"expiration\" field for a zero-value Certificate", cert)
+	}
+}
+
+func TestCSVWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w, _ := NewWriter("csv", &buf)
+
+	if err := w.Write([]Record{sampleRecord()}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want a header and one data row: %q", len(lines), buf.String())
+	}
+	if !strings.HasPrefix(lines[0], "distribution_id,arn,account_id") {
+		t.Errorf("header = %q, want it to start with distribution_id,arn,account_id", lines[0])
+	}
+	if !strings.Contains(lines[1], "E123456") {
+		t.Errorf("data row = %q, want it to contain the distribution ID", lines[1])
+	}
+}
+
+func TestCSVWriter_CertificateError(t *testing.T) {
+	var buf bytes.Buffer
+	w, _ := NewWriter("csv", &buf)
+
+	record := sampleRecord()
+	record.Certificate = Certificate{Error: "access denied"}
+
+	if err := w.Write([]Record{record}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	if !strings.Contains(buf.String(), "access denied") {
+		t.Errorf("CSV output = %q, want it to contain the certificate error", buf.String())
+	}
+}
+
+func TestTableWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w, _ := NewWriter("table", &buf)
+
+	if err := w.Write([]Record{sampleRecord()}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	got := buf.String()
+	if !strings.Contains(got, "DISTRIBUTION ID") || !strings.Contains(got, "CERT ERROR") {
+		t.Errorf("table header = %q, want DISTRIBUTION ID and CERT ERROR columns", got)
+	}
+	if !strings.Contains(got, "E123456") {
+		t.Errorf("table body = %q, want it to contain the distribution ID", got)
+	}
+}
+
+func TestJoinTags_DeterministicOrder(t *testing.T) {
+	tags := map[string]string{"env": "prod", "team": "core", "cost-center": "123"}
+
+	got := joinTags(tags)
+	want := "cost-center=123;env=prod;team=core"
+	if got != want {
+		t.Errorf("joinTags(%v) = %q, want %q", tags, got, want)
+	}
+}
+
+func TestJoinOrigins(t *testing.T) {
+	origins := []Origin{
+		{DomainName: "a.example.com", ProtocolPolicy: "https-only"},
+		{DomainName: "b.example.com", ProtocolPolicy: "s3"},
+	}
+
+	got := joinOrigins(origins)
+	want := "a.example.com(https-only);b.example.com(s3)"
+	if got != want {
+		t.Errorf("joinOrigins() = %q, want %q", got, want)
+	}
+}
+
+func TestJoinDomainValidations(t *testing.T) {
+	validations := []DomainValidation{
+		{DomainName: "www.example.com", ValidationStatus: "SUCCESS"},
+		{DomainName: "other.example.com", ValidationStatus: "PENDING_VALIDATION"},
+	}
+
+	got := joinDomainValidations(validations)
+	want := "www.example.com:SUCCESS;other.example.com:PENDING_VALIDATION"
+	if got != want {
+		t.Errorf("joinDomainValidations() = %q, want %q", got, want)
+	}
+}
+
+func TestJoinDNSChecks(t *testing.T) {
+	checks := []DNSCheck{
+		{Alias: "www.example.com", Status: "ok"},
+		{Alias: "other.example.com", Status: "missing"},
+	}
+
+	got := joinDNSChecks(checks)
+	want := "www.example.com:ok;other.example.com:missing"
+	if got != want {
+		t.Errorf("joinDNSChecks() = %q, want %q", got, want)
+	}
+}